@@ -2,12 +2,17 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"os/exec"
+	"os/signal"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -17,18 +22,185 @@ import (
 var opts = struct {
 	reportAllOutput  bool
 	waitBeforeDetach time.Duration
+	socketPath       string
+	killOnDetach     string
+	timeout          time.Duration
+	killAfter        time.Duration
+	logFile          string
+	syslogTag        string
+	notifyURL        string
+	mailAddr         string
+	stderrMode       string
 }{}
 
 func init() {
 	pflag.BoolVarP(&opts.reportAllOutput, "all", "a", false, "report all output after exit")
 	pflag.DurationVarP(&opts.waitBeforeDetach, "wait-before-detach", "w", 10*time.Second, "show output before detaching")
+	pflag.StringVar(&opts.socketPath, "socket", "", "expose output on this unix domain socket (default /tmp/later-<pid>.sock)")
+	pflag.StringVar(&opts.killOnDetach, "kill-on-detach", "", "send this signal to the child instead of detaching (e.g. TERM, KILL)")
+	pflag.DurationVar(&opts.timeout, "timeout", 0, "maximum runtime before the child is sent SIGTERM (0 disables)")
+	pflag.DurationVar(&opts.killAfter, "kill-after", 10*time.Second, "send SIGKILL if the child is still running this long after --timeout")
+	pflag.StringVar(&opts.logFile, "log-file", "", "write timestamped output to this file")
+	pflag.StringVar(&opts.syslogTag, "syslog", "", "stream output to the local syslog under this tag after detaching")
+	pflag.StringVar(&opts.notifyURL, "notify-url", "", "POST a JSON summary to this URL on exit")
+	pflag.StringVar(&opts.mailAddr, "mail", "", "mail a summary to this address on exit via sendmail")
+	pflag.StringVar(&opts.stderrMode, "stderr", "merge", "how to handle stderr: merge into stdout, capture separately, or drop")
 	pflag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: later [options] [--] cmd [arg]...\n\nOptions:\n")
+		fmt.Fprintf(os.Stderr, "Usage: later [options] [--] cmd [arg]...\n       later attach <socket>\n\nOptions:\n")
 		pflag.PrintDefaults()
 	}
 	pflag.Parse()
 }
 
+// forwardedSignals are the signals later forwards to the child's process
+// group. SIGCHLD is handled separately to reap reparented grandchildren.
+var forwardedSignals = []os.Signal{
+	syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT,
+	syscall.SIGUSR1, syscall.SIGUSR2, syscall.SIGWINCH,
+}
+
+// signalsByName maps the short name of every signal later knows about to
+// its syscall.Signal value, used by parseSignal and, inverted, by
+// signalName.
+var signalsByName = map[string]syscall.Signal{
+	"HUP": syscall.SIGHUP, "INT": syscall.SIGINT, "QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL, "TERM": syscall.SIGTERM, "USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2, "WINCH": syscall.SIGWINCH, "CONT": syscall.SIGCONT,
+	"STOP": syscall.SIGSTOP,
+}
+
+// parseSignal accepts a signal name ("TERM", "SIGTERM") or number ("15")
+// and returns the corresponding syscall.Signal.
+func parseSignal(name string) (syscall.Signal, error) {
+	if n, err := strconv.Atoi(name); err == nil {
+		return syscall.Signal(n), nil
+	}
+
+	name = strings.ToUpper(strings.TrimPrefix(name, "SIG"))
+
+	sig, ok := signalsByName[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown signal %q", name)
+	}
+
+	return sig, nil
+}
+
+// signalName renders sig the way a user would type it on the command
+// line ("SIGTERM"), rather than Go's lowercase strsignal-style text that
+// syscall.Signal's String method produces ("terminated").
+func signalName(sig syscall.Signal) string {
+	for name, s := range signalsByName {
+		if s == sig {
+			return "SIG" + name
+		}
+	}
+
+	return sig.String()
+}
+
+// backlogSize is the number of trailing bytes of output kept around for
+// clients that attach after some output has already been produced.
+const backlogSize = 256 * 1024
+
+// subscriberQueue is how many pending chunks we keep for a slow client
+// before giving up on it.
+const subscriberQueue = 64
+
+// outputBroadcaster fans out the child's output to any number of clients
+// connected via the unix domain socket, replaying a backlog of recent
+// output to newly attached clients first.
+type outputBroadcaster struct {
+	mu          sync.Mutex
+	backlog     []byte
+	subscribers map[chan []byte]struct{}
+	closed      bool
+}
+
+func newOutputBroadcaster() *outputBroadcaster {
+	return &outputBroadcaster{
+		subscribers: make(map[chan []byte]struct{}),
+	}
+}
+
+// Write appends p to the backlog and forwards it to all live subscribers,
+// dropping any subscriber that can't keep up.
+func (b *outputBroadcaster) Write(p []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.backlog = append(b.backlog, p...)
+	if len(b.backlog) > backlogSize {
+		b.backlog = b.backlog[len(b.backlog)-backlogSize:]
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- append([]byte(nil), p...):
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe registers a new client and returns the current backlog
+// together with a channel that receives all output written afterwards.
+func (b *outputBroadcaster) Subscribe() ([]byte, chan []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan []byte, subscriberQueue)
+	if b.closed {
+		close(ch)
+		return append([]byte(nil), b.backlog...), ch
+	}
+
+	b.subscribers[ch] = struct{}{}
+	return append([]byte(nil), b.backlog...), ch
+}
+
+// Close terminates all live subscribers. Further writes are ignored.
+func (b *outputBroadcaster) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for ch := range b.subscribers {
+		close(ch)
+		delete(b.subscribers, ch)
+	}
+}
+
+// outputStream identifies which of the child's streams a chunk of output
+// came from.
+type outputStream int
+
+const (
+	streamStdout outputStream = iota
+	streamStderr
+)
+
+func (s outputStream) String() string {
+	if s == streamStderr {
+		return "stderr"
+	}
+
+	return "stdout"
+}
+
+// OutputChunk is one line of output as recorded in the buffered log,
+// tagged with its originating stream and the time it was received.
+type OutputChunk struct {
+	Stream outputStream
+	Time   time.Time
+	Data   []byte
+}
+
 // Command bundles all data needed for running one command.
 type Command struct {
 	start time.Time
@@ -38,42 +210,261 @@ type Command struct {
 	exitError error
 	exited    chan struct{}
 
+	// streamsDone is released once every readStream goroutine has seen EOF,
+	// so wait() can be sure all output has reached the sinks and the chunk
+	// log before it reports the exit and closes c.exited.
+	streamsDone sync.WaitGroup
+
 	detachDelay time.Duration
 
 	detachMutex sync.Mutex
 	detached    bool
 
-	output *bytes.Buffer
+	chunksMutex sync.Mutex
+	chunks      []OutputChunk
+	chunksBytes int
+
+	broadcast  *outputBroadcaster
+	socketPath string
+	listener   net.Listener
+
+	killOnDetach syscall.Signal
+
+	// mainExited carries the wait status of the child if our SIGCHLD
+	// handler reaps it before c.Cmd.Wait() gets a chance to, which can
+	// happen since we act as a subreaper for the whole process group.
+	mainExited chan syscall.WaitStatus
+
+	timeout    time.Duration
+	killGrace  time.Duration
+	timedOut   atomic.Bool
+	forcedKill atomic.Bool
+
+	sinks []OutputSink
 }
 
 // Run starts the program.
 func (c *Command) Run() error {
 	c.exited = make(chan struct{})
-	c.output = bytes.NewBuffer(nil)
+	c.broadcast = newOutputBroadcaster()
+	c.mainExited = make(chan syscall.WaitStatus, 1)
 
-	c.Cmd.Stderr = os.Stderr
+	if c.Cmd.SysProcAttr == nil {
+		c.Cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	c.Cmd.SysProcAttr.Setpgid = true
 
-	stdout, err := c.Cmd.StdoutPipe()
+	stdout, stderr, mergedWriter, err := c.setupStreams()
 	if err != nil {
 		return err
 	}
 
-	go c.readOutput(stdout)
-	go c.detachAfter(c.detachDelay)
+	if err := c.listenSocket(); err != nil {
+		return err
+	}
+
+	sinks, err := newOutputSinks(c)
+	if err != nil {
+		return err
+	}
+	c.sinks = sinks
+
+	if err := setChildSubreaper(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: unable to become a subreaper: %v\n", err)
+	}
+
+	c.streamsDone.Add(1)
+	go func() {
+		defer c.streamsDone.Done()
+		c.readStream(stdout, streamStdout)
+	}()
+
+	if stderr != nil {
+		c.streamsDone.Add(1)
+		go func() {
+			defer c.streamsDone.Done()
+			c.readStream(stderr, streamStderr)
+		}()
+	}
 
 	err = c.Cmd.Start()
 	if err != nil {
 		return err
 	}
 
+	if mergedWriter != nil {
+		// Our copy of the write end must be closed so that readStream
+		// sees EOF once the child (which inherited its own copy) exits.
+		mergedWriter.Close()
+	}
+
+	// detachAfter and enforceTimeout read c.Process.Pid, so neither must
+	// start until Cmd.Start() has populated it.
+	go c.detachAfter(c.detachDelay)
+	go c.enforceTimeout()
+	go c.handleSignals()
 	go c.wait()
 	return nil
 }
 
+// setupStreams wires up the child's stdout and stderr according to
+// --stderr, returning the pipe(s) to read from. mergedWriter is non-nil
+// only in "merge" mode, where the caller must close it once the child has
+// started.
+func (c *Command) setupStreams() (stdout, stderr io.ReadCloser, mergedWriter *os.File, err error) {
+	switch opts.stderrMode {
+	case "drop":
+		c.Cmd.Stderr = io.Discard
+
+		stdout, err = c.Cmd.StdoutPipe()
+		return stdout, nil, nil, err
+
+	case "separate":
+		stdout, err = c.Cmd.StdoutPipe()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		stderr, err = c.Cmd.StderrPipe()
+		return stdout, stderr, nil, err
+
+	case "merge", "":
+		pr, pw, err := os.Pipe()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		c.Cmd.Stdout = pw
+		c.Cmd.Stderr = pw
+
+		return pr, nil, pw, nil
+
+	default:
+		return nil, nil, nil, fmt.Errorf("invalid --stderr mode %q, must be merge, separate or drop", opts.stderrMode)
+	}
+}
+
+// handleSignals forwards the signals in forwardedSignals to the child's
+// process group, and reaps any grandchildren reparented to us as a
+// subreaper whenever SIGCHLD arrives.
+func (c *Command) handleSignals() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, forwardedSignals...)
+	signal.Notify(ch, syscall.SIGCHLD)
+	defer signal.Stop(ch)
+
+	pgid := c.Process.Pid
+
+	for {
+		select {
+		case <-c.exited:
+			return
+		case sig := <-ch:
+			if sig == syscall.SIGCHLD {
+				c.reapOrphans()
+				continue
+			}
+
+			if err := syscall.Kill(-pgid, sig.(syscall.Signal)); err != nil {
+				fmt.Fprintf(os.Stderr, "forward %v to process group: %v\n", signalName(sig.(syscall.Signal)), err)
+			}
+		}
+	}
+}
+
+// reapOrphans collects any reparented grandchildren without blocking, so
+// they don't accumulate as zombies under our subreaper.
+func (c *Command) reapOrphans() {
+	for {
+		var status syscall.WaitStatus
+		pid, err := syscall.Wait4(-1, &status, syscall.WNOHANG, nil)
+		if pid <= 0 || err != nil {
+			return
+		}
+
+		if pid == c.Process.Pid {
+			select {
+			case c.mainExited <- status:
+			default:
+			}
+
+			continue
+		}
+
+		fmt.Printf("reaped orphaned grandchild pid %d (status %v)\n", pid, status)
+	}
+}
+
+// listenSocket opens the unix domain socket clients can `later attach` to
+// and starts accepting connections in the background.
+func (c *Command) listenSocket() error {
+	c.socketPath = opts.socketPath
+	if c.socketPath == "" {
+		c.socketPath = fmt.Sprintf("/tmp/later-%d.sock", os.Getpid())
+	}
+
+	os.Remove(c.socketPath)
+
+	l, err := net.Listen("unix", c.socketPath)
+	if err != nil {
+		return fmt.Errorf("listen on socket: %w", err)
+	}
+	c.listener = l
+
+	// The socket replays buffered command output, which may contain
+	// secrets, so only the owner should be able to connect.
+	if err := os.Chmod(c.socketPath, 0o600); err != nil {
+		return fmt.Errorf("chmod socket: %w", err)
+	}
+
+	fmt.Printf("listening for attach on %v\n", c.socketPath)
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go c.serveAttach(conn)
+		}
+	}()
+
+	return nil
+}
+
+// serveAttach replays the buffered backlog to a newly attached client and
+// then streams new output to it until the program exits or the client
+// goes away.
+func (c *Command) serveAttach(conn net.Conn) {
+	defer conn.Close()
+
+	backlog, ch := c.broadcast.Subscribe()
+	if _, err := conn.Write(backlog); err != nil {
+		return
+	}
+
+	for chunk := range ch {
+		if _, err := conn.Write(chunk); err != nil {
+			return
+		}
+	}
+}
+
 func (c *Command) detachAfter(d time.Duration) {
 	t := time.NewTimer(d)
 	<-t.C
 
+	if c.killOnDetach != 0 {
+		fmt.Printf("%v sending %v to process group instead of detaching\n", time.Now(), signalName(c.killOnDetach))
+
+		if err := syscall.Kill(-c.Process.Pid, c.killOnDetach); err != nil {
+			fmt.Fprintf(os.Stderr, "send %v to process group: %v\n", signalName(c.killOnDetach), err)
+		}
+
+		return
+	}
+
 	fmt.Printf("%v detaching\n", time.Now())
 
 	c.detachMutex.Lock()
@@ -81,71 +472,364 @@ func (c *Command) detachAfter(d time.Duration) {
 	c.detachMutex.Unlock()
 }
 
-func (c *Command) readOutput(rd io.ReadCloser) {
-	buf := make([]byte, 1*1024*1024)
+// enforceTimeout kills the child if it is still running after c.timeout,
+// escalating from SIGTERM to SIGKILL if it hasn't exited within
+// c.killGrace. It is a no-op if c.timeout is 0.
+func (c *Command) enforceTimeout() {
+	if c.timeout <= 0 {
+		return
+	}
+
+	t := time.NewTimer(c.timeout)
+	defer t.Stop()
+
+	select {
+	case <-c.exited:
+		return
+	case <-t.C:
+	}
+
+	c.timedOut.Store(true)
+	fmt.Printf("%v timeout of %v exceeded, sending SIGTERM to process group\n", time.Now(), c.timeout)
+
+	if err := syscall.Kill(-c.Process.Pid, syscall.SIGTERM); err != nil {
+		fmt.Fprintf(os.Stderr, "send SIGTERM to process group: %v\n", err)
+	}
+
+	grace := time.NewTimer(c.killGrace)
+	defer grace.Stop()
+
+	select {
+	case <-c.exited:
+		return
+	case <-grace.C:
+	}
+
+	c.forcedKill.Store(true)
+	fmt.Printf("%v still running %v after SIGTERM, sending SIGKILL\n", time.Now(), c.killGrace)
+
+	if err := syscall.Kill(-c.Process.Pid, syscall.SIGKILL); err != nil {
+		fmt.Fprintf(os.Stderr, "send SIGKILL to process group: %v\n", err)
+	}
+}
+
+// isDetached reports whether later has detached from the terminal yet.
+func (c *Command) isDetached() bool {
+	c.detachMutex.Lock()
+	defer c.detachMutex.Unlock()
+
+	return c.detached
+}
+
+// readStream reads rd as it arrives and forwards it live to the terminal
+// and the attach broadcaster, so an interactive prompt or unterminated
+// progress output still shows up immediately. In parallel it accumulates
+// complete lines for the sinks and the buffered chunk log, so that a line
+// split across two reads is never recorded as half a line on either side
+// of a detach.
+func (c *Command) readStream(rd io.ReadCloser, stream outputStream) {
+	buf := make([]byte, 64*1024)
+	var acc lineAccumulator
+
 	for {
 		buf = buf[:cap(buf)]
 		n, err := rd.Read(buf)
-		buf = buf[:n]
+		data := buf[:n]
 
-		c.detachMutex.Lock()
-		detached := c.detached
-		c.detachMutex.Unlock()
+		if len(data) > 0 {
+			c.writeLive(stream, data)
 
-		if detached || opts.reportAllOutput {
-			_, err := c.output.Write(buf)
-			if err != nil {
-				panic(err)
-			}
+			acc.Feed(data, err != nil, func(line []byte) {
+				c.handleChunk(stream, line)
+			})
 		}
 
-		if !detached {
-			_, err := os.Stdout.Write(buf)
-			if err != nil {
-				panic(err)
+		if err != nil {
+			if err != io.EOF {
+				fmt.Fprintf(os.Stderr, "read %v: %v\n", stream, err)
 			}
-		}
 
-		if err == io.EOF {
 			return
 		}
+	}
+}
 
-		if err != nil {
-			panic(err)
+// writeLive forwards a raw read as soon as it arrives to the attach
+// broadcaster and, while not yet detached, to the matching terminal
+// stream.
+func (c *Command) writeLive(stream outputStream, data []byte) {
+	c.broadcast.Write(data)
+
+	if c.isDetached() {
+		return
+	}
+
+	out := os.Stdout
+	if stream == streamStderr {
+		out = os.Stderr
+	}
+
+	if _, err := out.Write(data); err != nil {
+		panic(err)
+	}
+}
+
+// handleChunk fans one complete line of output out to the configured
+// sinks and, once detached (or with --all), the buffered chunk log.
+func (c *Command) handleChunk(stream outputStream, line []byte) {
+	for _, sink := range c.sinks {
+		if err := sink.Write(line); err != nil {
+			fmt.Fprintf(os.Stderr, "sink write: %v\n", err)
+		}
+	}
+
+	if c.isDetached() || opts.reportAllOutput {
+		c.appendChunk(stream, line)
+	}
+}
+
+// lineAccumulator splits a stream of arbitrary reads into complete
+// lines, carrying a trailing partial line over to the next Feed call.
+type lineAccumulator struct {
+	pending []byte
+}
+
+// Feed appends data and invokes emit for every complete line it
+// contains. If flush is set (the stream has ended), any remaining
+// partial line is emitted too.
+func (a *lineAccumulator) Feed(data []byte, flush bool, emit func(line []byte)) {
+	a.pending = append(a.pending, data...)
+
+	for {
+		i := bytes.IndexByte(a.pending, '\n')
+		if i < 0 {
+			break
+		}
+
+		emit(a.pending[:i+1])
+		a.pending = a.pending[i+1:]
+	}
+
+	if flush && len(a.pending) > 0 {
+		emit(a.pending)
+		a.pending = nil
+	}
+}
+
+// appendChunk records a line in the buffered chunk log, preserving the
+// stream it came from and the order lines across streams were received.
+// Like the attach broadcaster's backlog, the log is capped at backlogSize
+// bytes, dropping the oldest lines first, so a long-running detached job
+// can't grow it without bound.
+func (c *Command) appendChunk(stream outputStream, line []byte) {
+	c.chunksMutex.Lock()
+	defer c.chunksMutex.Unlock()
+
+	c.chunks = append(c.chunks, OutputChunk{
+		Stream: stream,
+		Time:   time.Now(),
+		Data:   append([]byte(nil), line...),
+	})
+	c.chunksBytes += len(line)
+
+	for c.chunksBytes > backlogSize && len(c.chunks) > 1 {
+		c.chunksBytes -= len(c.chunks[0].Data)
+		c.chunks = c.chunks[1:]
+	}
+}
+
+// replayChunks writes the buffered chunk log to the real stdout/stderr,
+// preserving each chunk's original stream identity and ordering.
+func (c *Command) replayChunks() {
+	c.chunksMutex.Lock()
+	defer c.chunksMutex.Unlock()
+
+	for _, chunk := range c.chunks {
+		out := os.Stdout
+		if chunk.Stream == streamStderr {
+			out = os.Stderr
 		}
+
+		out.Write(chunk.Data)
 	}
 }
 
-// wait blocks until the command exits.
+// tailOutput concatenates the buffered chunk log (at most backlogSize
+// bytes of trailing output) into a single string, for inclusion in sink
+// summaries.
+func (c *Command) tailOutput() string {
+	c.chunksMutex.Lock()
+	defer c.chunksMutex.Unlock()
+
+	var buf bytes.Buffer
+	for _, chunk := range c.chunks {
+		buf.Write(chunk.Data)
+	}
+
+	return buf.String()
+}
+
+// wait blocks until the command exits and records its wait status in
+// c.status.
 func (c *Command) wait() error {
 	defer close(c.exited)
-	err := c.Cmd.Wait()
+	defer c.closeSocket()
 
+	err := c.Cmd.Wait()
 	c.exitError = err
 
-	if _, ok := err.(*exec.ExitError); ok {
-		return nil
+	switch {
+	case err == nil:
+		// exited with status 0, c.status stays at its zero value
+	case errors.As(err, new(*exec.ExitError)):
+		if s, ok := err.(*exec.ExitError).Sys().(syscall.WaitStatus); ok {
+			c.status = s
+		}
+	case errors.Is(err, syscall.ECHILD):
+		// Our SIGCHLD handler, acting as a subreaper, reaped the child
+		// before Cmd.Wait() could; the status is waiting for us there.
+		select {
+		case c.status = <-c.mainExited:
+			c.exitError = nil
+		case <-time.After(time.Second):
+			// mainExited should already hold the status by the time
+			// Cmd.Wait() observes ECHILD, since our own reap is what
+			// caused it; if it still hasn't shown up, don't silently
+			// report a fabricated "exited normally" status.
+			c.exitError = fmt.Errorf("wait for child: lost exit status to subreaper race")
+		}
+	}
+
+	// Wait for the reader goroutines to drain the pipes and deliver every
+	// line to the sinks and the chunk log before we report the exit code
+	// and close c.exited, otherwise we can race ahead of them and report
+	// (or reply to an attach, or finish the sinks) before capture is done.
+	c.streamsDone.Wait()
+
+	c.finishSinks()
+
+	return c.exitError
+}
+
+// finishSinks fans the final exit summary out to every configured sink
+// and closes them.
+func (c *Command) finishSinks() {
+	if len(c.sinks) == 0 {
+		return
 	}
 
-	return err
+	summary := exitSummary{
+		Cmd:        c.Path,
+		Args:       c.Args[1:],
+		Start:      c.start,
+		End:        time.Now(),
+		ExitCode:   c.exitCodeLocked(),
+		Signaled:   c.status.Signaled(),
+		TailOutput: c.tailOutput(),
+	}
+	if summary.Signaled {
+		summary.Signal = c.status.Signal().String()
+	}
+
+	for _, sink := range c.sinks {
+		if err := sink.Finish(summary); err != nil {
+			fmt.Fprintf(os.Stderr, "sink finish: %v\n", err)
+		}
+
+		if err := sink.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "sink close: %v\n", err)
+		}
+	}
+}
+
+// closeSocket terminates all attached clients and removes the socket
+// file, it is called once the child has exited.
+func (c *Command) closeSocket() {
+	c.broadcast.Close()
+
+	if c.listener != nil {
+		c.listener.Close()
+	}
+
+	os.Remove(c.socketPath)
 }
 
-// WaitForExitCode returns the exit code of the command.
+// WaitForExitCode returns the exit code of the command, using the
+// conventional 128+signum for a child that was terminated by a signal.
 func (c *Command) WaitForExitCode() int {
 	<-c.exited
 
-	if c.exitError == nil {
-		return 0
+	return c.exitCodeLocked()
+}
+
+// exitCodeLocked computes the exit code from the currently recorded wait
+// status. It must only be called once the child has exited.
+func (c *Command) exitCodeLocked() int {
+	if c.exitError != nil {
+		if _, ok := c.exitError.(*exec.ExitError); !ok {
+			// Wait failed for a reason other than a non-zero exit
+			// status, and we weren't able to recover the real status.
+			return 127
+		}
 	}
 
-	if e, ok := c.exitError.(*exec.ExitError); ok {
-		if s, ok := e.Sys().(syscall.WaitStatus); ok {
-			c.status = s
-			return s.ExitStatus()
+	if c.timedOut.Load() {
+		if c.forcedKill.Load() {
+			return 137
 		}
+
+		return 124
+	}
+
+	return exitCodeFromStatus(c.status)
+}
+
+// exitCodeFromStatus derives a shell-like exit code from a wait status,
+// returning 128+signum for a signaled child (matching the convention used
+// by bash and GNU coreutils) or 127 if the status can't be interpreted.
+func exitCodeFromStatus(s syscall.WaitStatus) int {
+	switch {
+	case s.Exited():
+		return s.ExitStatus()
+	case s.Signaled():
+		return 128 + int(s.Signal())
+	default:
+		return 127
+	}
+}
+
+// statusString renders a wait status the way the final report line wants
+// it: plain exit code, or signal name plus core-dump indication.
+func statusString(s syscall.WaitStatus) string {
+	switch {
+	case s.Signaled():
+		msg := fmt.Sprintf("terminated by %s", signalName(s.Signal()))
+		if s.CoreDump() {
+			msg += " (core dumped)"
+		}
+
+		return fmt.Sprintf("%s exit=%d", msg, exitCodeFromStatus(s))
+	case s.Stopped():
+		return fmt.Sprintf("stopped by %s", signalName(s.StopSignal()))
+	default:
+		return fmt.Sprintf("exited normally exit=%d", exitCodeFromStatus(s))
+	}
+}
+
+// attach connects to the unix domain socket a running `later` instance
+// exposes and copies its output to stdout until the connection closes.
+func attach(socketPath string) {
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "attach to %v: %v\n", socketPath, err)
+		os.Exit(1)
 	}
+	defer conn.Close()
 
-	return 0
+	if _, err := io.Copy(os.Stdout, conn); err != nil && err != io.EOF {
+		fmt.Fprintf(os.Stderr, "attach to %v: %v\n", socketPath, err)
+		os.Exit(1)
+	}
 }
 
 func main() {
@@ -158,12 +842,41 @@ func main() {
 
 	cmdName, args := args[0], args[1:]
 
+	if cmdName == "attach" {
+		if len(args) != 1 {
+			fmt.Fprintf(os.Stderr, "usage: later attach <socket>\n")
+			os.Exit(1)
+		}
+
+		attach(args[0])
+		return
+	}
+
+	switch opts.stderrMode {
+	case "merge", "separate", "drop", "":
+	default:
+		fmt.Fprintf(os.Stderr, "--stderr: invalid mode %q, must be merge, separate or drop\n", opts.stderrMode)
+		os.Exit(1)
+	}
+
 	fmt.Printf("running: %v %v\n", cmdName, strings.Join(args, " "))
 
 	cmd := &Command{
 		Cmd:         exec.Command(cmdName, args...),
 		start:       time.Now(),
 		detachDelay: opts.waitBeforeDetach,
+		timeout:     opts.timeout,
+		killGrace:   opts.killAfter,
+	}
+
+	if opts.killOnDetach != "" {
+		sig, err := parseSignal(opts.killOnDetach)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "--kill-on-detach: %v\n", err)
+			os.Exit(1)
+		}
+
+		cmd.killOnDetach = sig
 	}
 
 	err := cmd.Run()
@@ -173,8 +886,15 @@ func main() {
 
 	exitCode := cmd.WaitForExitCode()
 
-	os.Stdout.Write(cmd.output.Bytes())
+	cmd.replayChunks()
+
+	status := statusString(cmd.status)
+	if cmd.timedOut.Load() {
+		status = fmt.Sprintf("timed out after %v, %s", cmd.timeout, status)
+	}
+
+	fmt.Printf("program terminated (%v) at %v (runtime %v)\n",
+		status, time.Now(), time.Since(cmd.start))
 
-	fmt.Printf("program terminated (exit code %d) at %v (runtime %v)\n",
-		exitCode, time.Now(), time.Since(cmd.start))
+	os.Exit(exitCode)
 }
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/syslog"
+	"net/http"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// exitSummary is the final report fanned out to every configured sink
+// once the child has exited.
+type exitSummary struct {
+	Cmd        string    `json:"cmd"`
+	Args       []string  `json:"args"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	ExitCode   int       `json:"exit_code"`
+	Signaled   bool      `json:"signaled"`
+	Signal     string    `json:"signal,omitempty"`
+	TailOutput string    `json:"tail_output"`
+}
+
+// OutputSink receives the child's output as it is produced and the final
+// exit summary once it terminates. Write is called symmetrically whether
+// or not later has detached, so a sink decides for itself whether it
+// cares about pre-detach output.
+type OutputSink interface {
+	Write(p []byte) error
+	Finish(summary exitSummary) error
+	Close() error
+}
+
+// newOutputSinks builds the sinks requested on the command line.
+func newOutputSinks(c *Command) ([]OutputSink, error) {
+	var sinks []OutputSink
+
+	if opts.logFile != "" {
+		// The log file carries the same buffered stdout/stderr content as
+		// the attach socket, which may contain secrets, so only the owner
+		// should be able to read it.
+		f, err := os.OpenFile(opts.logFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("open log file: %w", err)
+		}
+
+		sinks = append(sinks, &fileSink{f: f})
+	}
+
+	if opts.syslogTag != "" {
+		w, err := syslog.New(syslog.LOG_INFO, opts.syslogTag)
+		if err != nil {
+			return nil, fmt.Errorf("connect to syslog: %w", err)
+		}
+
+		sinks = append(sinks, &syslogSink{w: w, isDetached: c.isDetached})
+	}
+
+	if opts.notifyURL != "" {
+		sinks = append(sinks, &webhookSink{url: opts.notifyURL})
+	}
+
+	if opts.mailAddr != "" {
+		sinks = append(sinks, &mailSink{addr: opts.mailAddr})
+	}
+
+	return sinks, nil
+}
+
+// fileSink writes every line of output to a file, prefixed with the time
+// it was received.
+type fileSink struct {
+	f *os.File
+}
+
+func (s *fileSink) Write(p []byte) error {
+	ts := time.Now().Format(time.RFC3339)
+
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\n"), []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+
+		if _, err := fmt.Fprintf(s.f, "%s %s\n", ts, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *fileSink) Finish(summary exitSummary) error {
+	_, err := fmt.Fprintf(s.f, "%s program terminated, exit code %d\n",
+		time.Now().Format(time.RFC3339), summary.ExitCode)
+	return err
+}
+
+func (s *fileSink) Close() error {
+	return s.f.Close()
+}
+
+// syslogSink streams output lines to the local syslog daemon once later
+// has detached; before that the output is already visible on the
+// terminal.
+type syslogSink struct {
+	w          *syslog.Writer
+	isDetached func() bool
+}
+
+func (s *syslogSink) Write(p []byte) error {
+	if !s.isDetached() {
+		return nil
+	}
+
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\n"), []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+
+		if err := s.w.Info(string(line)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *syslogSink) Finish(summary exitSummary) error {
+	return s.w.Notice(fmt.Sprintf("command %q exited with code %d", summary.Cmd, summary.ExitCode))
+}
+
+func (s *syslogSink) Close() error {
+	return s.w.Close()
+}
+
+// sinkTimeout bounds how long a sink's Finish is allowed to block talking
+// to an external process or endpoint (sendmail, --notify-url), so an
+// unresponsive one can't hang later forever.
+const sinkTimeout = 10 * time.Second
+
+// webhookSink ignores individual output and POSTs the exit summary as
+// JSON once the child terminates.
+type webhookSink struct {
+	url string
+}
+
+func (s *webhookSink) Write(p []byte) error {
+	return nil
+}
+
+func (s *webhookSink) Finish(summary exitSummary) error {
+	body, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sinkTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("notify-url: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("notify-url: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notify-url: unexpected status %v", resp.Status)
+	}
+
+	return nil
+}
+
+func (s *webhookSink) Close() error {
+	return nil
+}
+
+// mailSink ignores individual output and, once the child terminates,
+// mails the exit summary and the tail of its output through sendmail.
+type mailSink struct {
+	addr string
+}
+
+func (s *mailSink) Write(p []byte) error {
+	return nil
+}
+
+func (s *mailSink) Finish(summary exitSummary) error {
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "To: %s\nSubject: later: %q exited with code %d\n\n",
+		s.addr, summary.Cmd, summary.ExitCode)
+	fmt.Fprintf(&body, "start: %v\nend: %v\nsignaled: %v\n\n%s\n",
+		summary.Start, summary.End, summary.Signaled, summary.TailOutput)
+
+	ctx, cancel := context.WithTimeout(context.Background(), sinkTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sendmail", "-t")
+	cmd.Stdin = &body
+
+	return cmd.Run()
+}
+
+func (s *mailSink) Close() error {
+	return nil
+}
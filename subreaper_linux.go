@@ -0,0 +1,22 @@
+//go:build linux
+
+package main
+
+import "syscall"
+
+// prSetChildSubreaper is PR_SET_CHILD_SUBREAPER from linux/prctl.h. It is
+// not exposed by the syscall package, so we spell it out here.
+const prSetChildSubreaper = 36
+
+// setChildSubreaper marks the current process as a subreaper, so that
+// grandchildren of the supervised process are reparented to us instead of
+// init when their immediate parent dies, letting us reap them instead of
+// leaving them orphaned.
+func setChildSubreaper() error {
+	_, _, errno := syscall.Syscall(syscall.SYS_PRCTL, prSetChildSubreaper, 1, 0)
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package main
+
+// setChildSubreaper is a no-op on platforms without prctl(2).
+func setChildSubreaper() error {
+	return nil
+}